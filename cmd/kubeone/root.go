@@ -0,0 +1,93 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	kubeoneapi "github.com/kubermatic/kubeone/pkg/apis/kubeone"
+	"github.com/kubermatic/kubeone/pkg/ssh"
+	"github.com/kubermatic/kubeone/pkg/state"
+)
+
+// globalOptions are the flags shared by every subcommand.
+type globalOptions struct {
+	Manifest    string
+	Verbose     bool
+	FlapSamples int
+	FlapWindow  time.Duration
+}
+
+// newGlobalOptions registers the global flags on rootFlags and returns the
+// struct subcommands read them back from once the command runs.
+func newGlobalOptions(rootFlags *pflag.FlagSet) *globalOptions {
+	opts := &globalOptions{}
+
+	rootFlags.StringVarP(&opts.Manifest, "manifest", "m", "kubeone.yaml", "path to the cluster manifest")
+	rootFlags.BoolVarP(&opts.Verbose, "verbose", "v", false, "enable verbose logging")
+	rootFlags.IntVar(&opts.FlapSamples, "flap-samples", 0, "number of times to re-sample the kubelet/container runtime units when probing for a restart flap loop (0 uses the built-in default)")
+	rootFlags.DurationVar(&opts.FlapWindow, "flap-window", 0, "spacing between flap-detection samples (0 uses the built-in default)")
+
+	return opts
+}
+
+// BuildState loads the cluster manifest and assembles the State every task
+// is run against.
+func (g *globalOptions) BuildState() (*state.State, error) {
+	cluster, err := kubeoneapi.LoadManifest(g.Manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := logrus.New()
+	if g.Verbose {
+		logger.SetLevel(logrus.DebugLevel)
+	}
+
+	return &state.State{
+		Context:      context.Background(),
+		Logger:       logger,
+		Cluster:      cluster,
+		Connector:    ssh.NewOpener(),
+		FlapSamples:  g.FlapSamples,
+		FlapInterval: g.FlapWindow,
+	}, nil
+}
+
+// rootCmd builds the top-level `kubeone` command and wires in every
+// subcommand.
+func rootCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "kubeone",
+		Short: "KubeOne: the operations tool for Kubernetes clusters",
+	}
+
+	// Registered once here: statusCmd/upgradeCmd share this same
+	// globalOptions instance rather than each registering --manifest/
+	// --verbose on the command's flag set again.
+	gopts := newGlobalOptions(cmd.PersistentFlags())
+
+	cmd.AddCommand(statusCmd(gopts))
+	cmd.AddCommand(upgradeCmd(gopts))
+
+	return cmd
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kubermatic/kubeone/pkg/clusterstatus/report"
+	"github.com/kubermatic/kubeone/pkg/tasks"
+)
+
+type statusOpts struct {
+	globalOptions
+	Output string
+}
+
+func statusCmd(gopts *globalOptions) *cobra.Command {
+	opts := &statusOpts{}
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Investigate the state of a cluster",
+		Long:  "Probe every control plane and static worker host over SSH and print the reconciled cluster state",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.globalOptions = *gopts
+			return runStatus(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Output, "output", "text", "output format: text, json, or yaml")
+
+	return cmd
+}
+
+func runStatus(opts *statusOpts) error {
+	s, err := opts.BuildState()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	sink, err := report.NewSink(opts.Output, os.Stdout)
+	if err != nil {
+		return err
+	}
+	s.ReportSink = sink
+
+	return tasks.RunProbes(s)
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kubermatic/kubeone/pkg/state"
+	"github.com/kubermatic/kubeone/pkg/tasks"
+	"github.com/kubermatic/kubeone/pkg/upgrade/plan"
+)
+
+type upgradeOpts struct {
+	globalOptions
+	Plan             bool
+	TargetKubernetes string
+	TargetAddons     string
+	TargetOSImage    string
+}
+
+func upgradeCmd(gopts *globalOptions) *cobra.Command {
+	opts := &upgradeOpts{}
+
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Upgrade the cluster to a newer Kubernetes version",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.globalOptions = *gopts
+			return runUpgrade(opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Plan, "plan", false, "print the upgrade plan instead of applying it")
+	cmd.Flags().StringVar(&opts.TargetKubernetes, "target-kubernetes", "", "target Kubernetes version (defaults to the version configured in the manifest)")
+	cmd.Flags().StringVar(&opts.TargetAddons, "target-addons", "", "target addons version")
+	cmd.Flags().StringVar(&opts.TargetOSImage, "target-os-image", "", "target host OS image")
+
+	return cmd
+}
+
+func runUpgrade(opts *upgradeOpts) error {
+	s, err := opts.BuildState()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if err := tasks.RunProbes(s); err != nil {
+		return err
+	}
+
+	targetKubernetes := opts.TargetKubernetes
+	if targetKubernetes == "" {
+		targetKubernetes = s.Cluster.Versions.Kubernetes
+	}
+
+	coordinated, err := plan.ComputeCoordinated(s.LiveCluster, plan.Targets{
+		Kubernetes: targetKubernetes,
+		Addons:     opts.TargetAddons,
+		OSImage:    opts.TargetOSImage,
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.Plan {
+		return printUpgradePlan(coordinated)
+	}
+
+	return applyUpgradePlan(s, coordinated)
+}
+
+func printUpgradePlan(p *plan.CoordinatedPlan) error {
+	if p.Kubernetes != nil {
+		fmt.Println("Kubernetes:")
+		fmt.Print(p.Kubernetes.String())
+	}
+	if p.Addons != nil {
+		fmt.Printf("Addons: %s -> %s\n", p.Addons.FromVersion, p.Addons.ToVersion)
+	}
+	if p.OSImage != nil {
+		fmt.Printf("OS image: %s -> %s\n", p.OSImage.FromImage, p.OSImage.ToImage)
+	}
+
+	return nil
+}
+
+// applyUpgradePlan drives the existing upgrade tasks in the order the
+// CoordinatedPlan prescribes instead of the previously hardcoded step order.
+func applyUpgradePlan(s *state.State, p *plan.CoordinatedPlan) error {
+	if p.Kubernetes != nil {
+		if err := tasks.ApplyUpgradePlan(s, p.Kubernetes); err != nil {
+			return err
+		}
+	}
+	if p.Addons != nil {
+		if err := tasks.ApplyAddonsUpgrade(s, p.Addons.ToVersion); err != nil {
+			return err
+		}
+	}
+	if p.OSImage != nil {
+		if err := tasks.ApplyOSImageUpgrade(s, p.OSImage.ToImage); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
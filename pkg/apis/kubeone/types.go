@@ -0,0 +1,88 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kubeone holds the user-facing cluster configuration: what KubeOne
+// was told to provision, as opposed to pkg/state.Cluster, which holds what
+// was actually probed on the wire.
+package kubeone
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// OperatingSystemName identifies the OS family running on a host, which
+// determines how KubeOne detects and manages packages on it.
+type OperatingSystemName string
+
+const (
+	OperatingSystemNameUbuntu  OperatingSystemName = "ubuntu"
+	OperatingSystemNameCentOS  OperatingSystemName = "centos"
+	OperatingSystemNameRHEL    OperatingSystemName = "rhel"
+	OperatingSystemNameCoreOS  OperatingSystemName = "coreos"
+	OperatingSystemNameFlatcar OperatingSystemName = "flatcar"
+)
+
+// HostConfig describes a single configured host, control plane or static
+// worker.
+type HostConfig struct {
+	Hostname        string
+	PublicAddress   string
+	PrivateAddress  string
+	OperatingSystem OperatingSystemName
+
+	// IsLeader is set by investigateCluster once a control plane leader is
+	// elected; it is not part of the user-supplied configuration.
+	IsLeader bool
+}
+
+// HostConfigSpec is a named group of hosts, e.g. the control plane or the
+// static workers.
+type HostConfigSpec struct {
+	Hosts []HostConfig
+}
+
+// VersionConfig pins the software versions KubeOne provisions the cluster
+// with.
+type VersionConfig struct {
+	Kubernetes string
+}
+
+// KubeOneCluster is the root of the user-supplied cluster manifest.
+type KubeOneCluster struct {
+	Name string
+
+	ControlPlane  HostConfigSpec
+	StaticWorkers HostConfigSpec
+	Versions      VersionConfig
+}
+
+// LoadManifest reads and parses a KubeOneCluster manifest from path.
+func LoadManifest(path string) (*KubeOneCluster, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read manifest %q", path)
+	}
+
+	cluster := &KubeOneCluster{}
+	if err := yaml.Unmarshal(buf, cluster); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse manifest %q", path)
+	}
+
+	return cluster, nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apiserverstatus probes a single control plane host's kube-apiserver
+// for health.
+package apiserverstatus
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"time"
+
+	kubeoneapi "github.com/kubermatic/kubeone/pkg/apis/kubeone"
+	"github.com/kubermatic/kubeone/pkg/state"
+)
+
+// Status is the result of probing one host's kube-apiserver.
+type Status struct {
+	Health bool
+}
+
+// requestTimeout bounds how long Get waits on a single host: a firewalled or
+// hung apiserver must surface as unhealthy rather than block the probe
+// series indefinitely.
+const requestTimeout = 10 * time.Second
+
+// Get hits https://<host>:6443/healthz and reports whether the apiserver
+// answered healthy.
+func Get(s *state.State, host kubeoneapi.HostConfig) (Status, error) {
+	addr := host.PrivateAddress
+	if addr == "" {
+		addr = host.PublicAddress
+	}
+
+	client := http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
+		},
+		Timeout: requestTimeout,
+	}
+
+	ctx, cancel := context.WithTimeout(s.Context, requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+addr+":6443/healthz", nil)
+	if err != nil {
+		return Status{}, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Status{}, err
+	}
+	defer resp.Body.Close()
+
+	return Status{Health: resp.StatusCode == http.StatusOK}, nil
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package etcdstatus probes the etcd cluster member list and per-member
+// health via etcdctl over each control plane host's own SSH connection.
+package etcdstatus
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	kubeoneapi "github.com/kubermatic/kubeone/pkg/apis/kubeone"
+	"github.com/kubermatic/kubeone/pkg/state"
+)
+
+const (
+	// etcdctlEnv points etcdctl at the local etcd instance using the
+	// healthcheck client certificate kubeadm always provisions.
+	etcdctlEnv = `ETCDCTL_API=3 ETCDCTL_CACERT=/etc/kubernetes/pki/etcd/ca.crt ` +
+		`ETCDCTL_CERT=/etc/kubernetes/pki/etcd/healthcheck-client.crt ` +
+		`ETCDCTL_KEY=/etc/kubernetes/pki/etcd/healthcheck-client.key`
+
+	memberListCMD     = etcdctlEnv + ` etcdctl --endpoints=https://127.0.0.1:2379 member list -w json`
+	endpointHealthCMD = etcdctlEnv + ` etcdctl --endpoints=https://127.0.0.1:2379 endpoint health -w json`
+)
+
+// MemberListResult is the etcd membership as last queried from the cluster.
+type MemberListResult struct {
+	Members []string
+}
+
+// Status is the result of probing one host's etcd member.
+type Status struct {
+	Member bool
+	Health bool
+}
+
+type memberListResponse struct {
+	Members []struct {
+		Name string `json:"name"`
+	} `json:"members"`
+}
+
+type endpointHealthResponse struct {
+	Health bool `json:"health"`
+}
+
+// MemberList asks the etcd instance on each control plane host in turn for
+// the member list, falling back to the next host on connection/exec failure
+// so one unreachable host doesn't fail the whole probe when the rest of the
+// control plane (and etcd) is healthy. Get can then tell which hosts are
+// actually etcd members rather than merely configured as control plane.
+func MemberList(s *state.State) (MemberListResult, error) {
+	if len(s.LiveCluster.ControlPlane) == 0 {
+		return MemberListResult{}, errors.New("no control plane hosts to query etcd member list from")
+	}
+
+	var lastErr error
+	for i := range s.LiveCluster.ControlPlane {
+		host := s.LiveCluster.ControlPlane[i].Config
+
+		members, err := memberListFrom(s, *host)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		return members, nil
+	}
+
+	return MemberListResult{}, errors.Wrap(lastErr, "unable to list etcd members from any control plane host")
+}
+
+func memberListFrom(s *state.State, host kubeoneapi.HostConfig) (MemberListResult, error) {
+	conn, err := s.Connector.Connect(host)
+	if err != nil {
+		return MemberListResult{}, errors.Wrapf(err, "unable to connect to %q to list etcd members", host.Hostname)
+	}
+
+	out, _, _, err := conn.Exec(memberListCMD)
+	if err != nil {
+		return MemberListResult{}, errors.Wrapf(err, "unable to list etcd members via %q", host.Hostname)
+	}
+
+	var resp memberListResponse
+	if err := json.Unmarshal([]byte(out), &resp); err != nil {
+		return MemberListResult{}, errors.Wrap(err, "unable to parse etcdctl member list output")
+	}
+
+	members := MemberListResult{}
+	for _, m := range resp.Members {
+		members.Members = append(members.Members, m.Name)
+	}
+
+	return members, nil
+}
+
+// Get reports whether host is a member of the etcd cluster and, if so,
+// whether etcdctl reports its endpoint healthy.
+func Get(s *state.State, host kubeoneapi.HostConfig, members MemberListResult) (*Status, error) {
+	status := &Status{}
+	for _, m := range members.Members {
+		if m == host.Hostname {
+			status.Member = true
+			break
+		}
+	}
+
+	if !status.Member {
+		return status, nil
+	}
+
+	conn, err := s.Connector.Connect(host)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to connect to %q to check etcd health", host.Hostname)
+	}
+
+	out, _, _, err := conn.Exec(endpointHealthCMD)
+	if err != nil {
+		// etcdctl exits non-zero when the endpoint is unhealthy; that's a
+		// result, not a probe failure.
+		return status, nil
+	}
+
+	var resp []endpointHealthResponse
+	if err := json.Unmarshal([]byte(out), &resp); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse etcdctl endpoint health output for %q", host.Hostname)
+	}
+
+	status.Health = len(resp) > 0 && resp[0].Health
+
+	return status, nil
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+type jsonSink struct {
+	w io.Writer
+}
+
+// NewJSONSink returns a Sink that marshals the ClusterReport as indented
+// JSON, suitable for `kubeone status --output=json`.
+func NewJSONSink(w io.Writer) Sink {
+	return &jsonSink{w: w}
+}
+
+func (s *jsonSink) WriteClusterReport(cr ClusterReport) error {
+	enc := json.NewEncoder(s.w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(cr); err != nil {
+		return errors.Wrap(err, "unable to encode cluster report as json")
+	}
+
+	return nil
+}
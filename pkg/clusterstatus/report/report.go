@@ -0,0 +1,64 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package report defines the machine-readable shape of a probe/investigation
+// result and the sinks (text/json/yaml) that can render it.
+package report
+
+// ComponentStatus is the decoded, human- and machine-readable view of a
+// state.Host component's systemd flag bits.
+type ComponentStatus struct {
+	Installed    bool   `json:"installed" yaml:"installed"`
+	Running      bool   `json:"running" yaml:"running"`
+	Active       bool   `json:"active" yaml:"active"`
+	Restarting   bool   `json:"restarting" yaml:"restarting"`
+	RestartCount int    `json:"restartCount" yaml:"restartCount"`
+	FlapDetected bool   `json:"flapDetected" yaml:"flapDetected"`
+	Version      string `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+// HostReport is the per-host result of investigateHost.
+type HostReport struct {
+	Hostname string `json:"hostname" yaml:"hostname"`
+	Role     string `json:"role" yaml:"role"`
+
+	ContainerRuntimeName string          `json:"containerRuntimeName" yaml:"containerRuntimeName"`
+	ContainerRuntime     ComponentStatus `json:"containerRuntime" yaml:"containerRuntime"`
+	Kubelet              ComponentStatus `json:"kubelet" yaml:"kubelet"`
+	KubeletInitialized   bool            `json:"kubeletInitialized" yaml:"kubeletInitialized"`
+
+	APIServer ComponentStatus `json:"apiServer" yaml:"apiServer"`
+	Etcd      ComponentStatus `json:"etcd" yaml:"etcd"`
+
+	IsLeader    bool `json:"isLeader" yaml:"isLeader"`
+	IsInCluster bool `json:"isInCluster" yaml:"isInCluster"`
+}
+
+// ClusterReport is the cluster-wide result of investigateCluster.
+type ClusterReport struct {
+	Hosts []HostReport `json:"hosts" yaml:"hosts"`
+
+	QuorumHealthy bool `json:"quorumHealthy" yaml:"quorumHealthy"`
+
+	HostsToBeProvisioned []string `json:"hostsToBeProvisioned" yaml:"hostsToBeProvisioned"`
+	NodesToBeRemoved     []string `json:"nodesToBeRemoved" yaml:"nodesToBeRemoved"`
+}
+
+// Sink renders a ClusterReport to its configured destination, in whatever
+// format it was constructed with (text/json/yaml).
+type Sink interface {
+	WriteClusterReport(ClusterReport) error
+}
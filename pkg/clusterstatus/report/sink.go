@@ -0,0 +1,38 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// NewSink returns the Sink registered for the given --output format. An
+// empty format defaults to "text", KubeOne's original behavior.
+func NewSink(format string, w io.Writer) (Sink, error) {
+	switch format {
+	case "", "text":
+		return NewTextSink(w), nil
+	case "json":
+		return NewJSONSink(w), nil
+	case "yaml":
+		return NewYAMLSink(w), nil
+	default:
+		return nil, errors.Errorf("unknown output format %q, must be one of: text, json, yaml", format)
+	}
+}
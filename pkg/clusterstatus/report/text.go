@@ -0,0 +1,80 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"io"
+)
+
+// textSink renders a ClusterReport in the free-form layout runProbes used to
+// print directly with fmt.Println, before the report was made structured.
+// Per host it also prints the role, the container runtime name and the flap
+// sampler results, and labels the runtime lines "container runtime" rather
+// than "docker".
+type textSink struct {
+	w io.Writer
+}
+
+// NewTextSink returns a Sink that writes KubeOne's free-form, human-readable
+// probe output.
+func NewTextSink(w io.Writer) Sink {
+	return &textSink{w: w}
+}
+
+func (s *textSink) WriteClusterReport(cr ClusterReport) error {
+	for _, host := range cr.Hosts {
+		fmt.Fprintln(s.w, "---------------")
+		fmt.Fprintf(s.w, "host: %q\n", host.Hostname)
+		fmt.Fprintf(s.w, "role: %q\n", host.Role)
+		fmt.Fprintf(s.w, "container runtime: %q\n", host.ContainerRuntimeName)
+		fmt.Fprintf(s.w, "container runtime version: %q\n", host.ContainerRuntime.Version)
+		fmt.Fprintf(s.w, "container runtime is installed?: %t\n", host.ContainerRuntime.Installed)
+		fmt.Fprintf(s.w, "container runtime is running?: %t\n", host.ContainerRuntime.Running)
+		fmt.Fprintf(s.w, "container runtime is active?: %t\n", host.ContainerRuntime.Active)
+		fmt.Fprintf(s.w, "container runtime is restarting?: %t\n", host.ContainerRuntime.Restarting)
+		fmt.Fprintf(s.w, "container runtime is flapping?: %t (restarts: %d)\n", host.ContainerRuntime.FlapDetected, host.ContainerRuntime.RestartCount)
+		fmt.Fprintln(s.w)
+
+		fmt.Fprintf(s.w, "kubelet version: %q\n", host.Kubelet.Version)
+		fmt.Fprintf(s.w, "kubelet is installed?: %t\n", host.Kubelet.Installed)
+		fmt.Fprintf(s.w, "kubelet is running?: %t\n", host.Kubelet.Running)
+		fmt.Fprintf(s.w, "kubelet is active?: %t\n", host.Kubelet.Active)
+		fmt.Fprintf(s.w, "kubelet is restarting?: %t\n", host.Kubelet.Restarting)
+		fmt.Fprintf(s.w, "kubelet is flapping?: %t (restarts: %d)\n", host.Kubelet.FlapDetected, host.Kubelet.RestartCount)
+		fmt.Fprintf(s.w, "kubelet is initialized?: %t\n", host.KubeletInitialized)
+		fmt.Fprintln(s.w)
+	}
+
+	fmt.Fprintln(s.w)
+	fmt.Fprintln(s.w, "---------------")
+	fmt.Fprintf(s.w, "Unprovisioned hosts: %q\n", cr.HostsToBeProvisioned)
+	fmt.Fprintf(s.w, "Nodes to be removed: %q\n", cr.NodesToBeRemoved)
+	fmt.Fprintln(s.w)
+
+	fmt.Fprintln(s.w, "---------------")
+	for _, host := range cr.Hosts {
+		if host.Role != "control-plane" {
+			continue
+		}
+		fmt.Fprintf(s.w, "API server running on %q: %t\n", host.Hostname, host.APIServer.Running)
+		fmt.Fprintf(s.w, "Etcd running on %q: %t\n", host.Hostname, host.Etcd.Running)
+	}
+	fmt.Fprintln(s.w)
+
+	return nil
+}
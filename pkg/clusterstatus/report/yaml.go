@@ -0,0 +1,44 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+type yamlSink struct {
+	w io.Writer
+}
+
+// NewYAMLSink returns a Sink that marshals the ClusterReport as YAML.
+func NewYAMLSink(w io.Writer) Sink {
+	return &yamlSink{w: w}
+}
+
+func (s *yamlSink) WriteClusterReport(cr ClusterReport) error {
+	buf, err := yaml.Marshal(cr)
+	if err != nil {
+		return errors.Wrap(err, "unable to encode cluster report as yaml")
+	}
+
+	_, err = s.w.Write(buf)
+
+	return err
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apiversion picks the kubeadm config API version a host's kubeadm
+// binary actually understands, mirroring the template-selection approach
+// minikube uses in bsutil/kubeadm.go.
+package apiversion
+
+import "github.com/Masterminds/semver"
+
+const (
+	V1beta1 = "kubeadm.k8s.io/v1beta1"
+	V1beta2 = "kubeadm.k8s.io/v1beta2"
+	V1beta3 = "kubeadm.k8s.io/v1beta3"
+)
+
+// Select returns the kubeadm config API version to render for a host.
+//
+// min is the version currently installed on the host (the kubeadm binary
+// that will actually parse the rendered config), and max is the version
+// KubeOne is driving the cluster towards. During a join or a fresh install
+// there is no "currently installed" kubeadm yet, so min may be nil and the
+// selection falls back to max.
+//
+// The API version is picked for min rather than max because, during a
+// rolling upgrade, it's the node's current (not-yet-upgraded) kubeadm that
+// has to understand the config KubeOne renders for it.
+func Select(min, max *semver.Version) string {
+	v := min
+	if v == nil {
+		v = max
+	}
+	if v == nil {
+		return V1beta3
+	}
+
+	switch {
+	case v.Minor() <= 14:
+		return V1beta1
+	case v.Minor() <= 21:
+		return V1beta2
+	default:
+		return V1beta3
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiversion
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver"
+)
+
+func mustVersion(t *testing.T, v string) *semver.Version {
+	t.Helper()
+
+	parsed, err := semver.NewVersion(v)
+	if err != nil {
+		t.Fatalf("failed to parse version %q: %v", v, err)
+	}
+
+	return parsed
+}
+
+func TestSelect(t *testing.T) {
+	testCases := []struct {
+		name     string
+		min, max string
+		expected string
+	}{
+		{
+			name:     "min picks v1beta1 for old kubeadm",
+			min:      "1.13.5",
+			max:      "1.22.0",
+			expected: V1beta1,
+		},
+		{
+			name:     "min picks v1beta2",
+			min:      "1.21.3",
+			max:      "1.23.0",
+			expected: V1beta2,
+		},
+		{
+			name:     "min picks v1beta3 for new kubeadm",
+			min:      "1.24.0",
+			max:      "1.25.0",
+			expected: V1beta3,
+		},
+		{
+			name:     "falls back to max when min is unknown",
+			max:      "1.13.0",
+			expected: V1beta1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var minVer *semver.Version
+			if tc.min != "" {
+				minVer = mustVersion(t, tc.min)
+			}
+
+			got := Select(minVer, mustVersion(t, tc.max))
+			if got != tc.expected {
+				t.Errorf("Select() = %q, expected %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSelectNilBoth(t *testing.T) {
+	if got := Select(nil, nil); got != V1beta3 {
+		t.Errorf("Select(nil, nil) = %q, expected %q", got, V1beta3)
+	}
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kubeconfig builds the dynamic client used to reconcile live
+// cluster state against the Kubernetes API, once the cluster has been
+// bootstrapped.
+package kubeconfig
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	dynclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubermatic/kubeone/pkg/state"
+)
+
+// BuildKubernetesClientset loads the cluster's kubeconfig and assigns a
+// DynamicClient built from it to s, so later tasks no longer need to do it
+// themselves.
+func BuildKubernetesClientset(s *state.State) error {
+	cfg, err := clientcmd.BuildConfigFromFlags("", clientcmd.RecommendedHomeFile)
+	if err != nil {
+		return errors.Wrap(err, "unable to build Kubernetes client configuration")
+	}
+
+	client, err := dynclient.New(cfg, dynclient.Options{})
+	if err != nil {
+		return errors.Wrap(err, "unable to build Kubernetes client")
+	}
+
+	s.DynamicClient = client
+
+	return nil
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ssh provides the minimal connection abstraction tasks use to run
+// commands on a host, regardless of how that connection was established
+// (direct SSH, a bastion hop, etc.).
+package ssh
+
+import (
+	"github.com/pkg/errors"
+
+	kubeoneapi "github.com/kubermatic/kubeone/pkg/apis/kubeone"
+)
+
+// Connection runs commands on a single, already-established host
+// connection.
+type Connection interface {
+	// Exec runs cmd and returns its stdout, stderr and exit code.
+	Exec(cmd string) (stdout string, stderr string, exitcode int, err error)
+}
+
+// Connector opens Connections to the hosts described by the cluster
+// configuration.
+type Connector interface {
+	Connect(host kubeoneapi.HostConfig) (Connection, error)
+}
+
+// Opener is the default, not-yet-implemented Connector. Wiring it up to an
+// actual SSH client is out of scope here; callers get an actionable error
+// instead of a nil-pointer panic.
+type Opener struct{}
+
+func NewOpener() *Opener {
+	return &Opener{}
+}
+
+func (*Opener) Connect(host kubeoneapi.HostConfig) (Connection, error) {
+	return nil, errors.Errorf("no SSH connector configured, unable to connect to %q", host.Hostname)
+}
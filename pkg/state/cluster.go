@@ -0,0 +1,129 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"sync"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+
+	kubeoneapi "github.com/kubermatic/kubeone/pkg/apis/kubeone"
+)
+
+// Component status flags. ComponentInstalled and the SystemD* flags are
+// decoded from `systemctl show` by pkg/tasks.systemdStatus;
+// KubeletInitialized and PodRunning are set by the other probes.
+const (
+	ComponentInstalled uint64 = 1 << iota
+	SystemDStatusActive
+	SystemDStatusRunning
+	SystemDStatusRestarting
+	SystemDStatusUnknown
+	SystemdDStatusDead
+	KubeletInitialized
+	PodRunning
+)
+
+// ComponentState is the probed status of one systemd-managed component on a
+// host (kubelet, apiserver, etcd).
+type ComponentState struct {
+	Status  uint64
+	Version *semver.Version
+
+	// RestartCount/FlapDetected are filled in by the bounded flap sampler;
+	// FlapDetected means the component was seen restarting repeatedly
+	// across the sampling window, not just once.
+	RestartCount int
+	FlapDetected bool
+}
+
+// ContainerRuntimeState additionally records which container runtime
+// (docker/containerd/crio) was found running, since a host may have more
+// than one loaded.
+type ContainerRuntimeState struct {
+	ComponentState
+	RuntimeName string
+}
+
+// Host is the probed, live state of a single control plane or static worker
+// host.
+type Host struct {
+	Config *kubeoneapi.HostConfig
+
+	ContainerRuntime ContainerRuntimeState
+	Kubelet          ComponentState
+	APIServer        ComponentState
+	Etcd             ComponentState
+
+	IsInCluster bool
+}
+
+// Cluster is the live state of the whole cluster as last probed by
+// pkg/tasks.RunProbes: what runProbes found on the control plane, on the
+// static workers, and what investigateCluster reconciled against the
+// Kubernetes API.
+type Cluster struct {
+	Lock sync.Mutex
+
+	ExpectedVersion *semver.Version
+
+	ControlPlane []Host
+	Workers      []Host
+
+	// AddonsVersion/OSImage track the cluster-wide, not per-host, upgrade
+	// targets consumed by pkg/upgrade/plan.
+	AddonsVersion string
+	OSImage       string
+}
+
+// IsProvisioned reports whether the cluster has already been bootstrapped,
+// i.e. whether at least one control plane host has an initialized kubelet.
+// Before that point there's no live Kubernetes API to reconcile against.
+func (c *Cluster) IsProvisioned() bool {
+	if c == nil {
+		return false
+	}
+
+	for i := range c.ControlPlane {
+		if c.ControlPlane[i].Kubelet.Status&KubeletInitialized != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FindHost looks up a host by hostname across both the control plane and
+// the static workers.
+func (c *Cluster) FindHost(hostname string) (*Host, error) {
+	c.Lock.Lock()
+	defer c.Lock.Unlock()
+
+	for i := range c.ControlPlane {
+		if c.ControlPlane[i].Config.Hostname == hostname {
+			return &c.ControlPlane[i], nil
+		}
+	}
+	for i := range c.Workers {
+		if c.Workers[i].Config.Hostname == hostname {
+			return &c.Workers[i], nil
+		}
+	}
+
+	return nil, errors.Errorf("host %q not found in the live cluster", hostname)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package state carries everything a task needs to run: the desired
+// configuration, the probed live state of the cluster, and the shared
+// clients/connections used to talk to it.
+package state
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	dynclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubeoneapi "github.com/kubermatic/kubeone/pkg/apis/kubeone"
+	"github.com/kubermatic/kubeone/pkg/clusterstatus/report"
+	"github.com/kubermatic/kubeone/pkg/ssh"
+)
+
+// RunMode controls how RunTaskOn* fans a task out across hosts.
+type RunMode int
+
+const (
+	RunParallel RunMode = iota
+	RunSequential
+)
+
+// HostTask is a unit of work to run against a single host over its SSH
+// connection.
+type HostTask func(s *State, host *kubeoneapi.HostConfig, conn ssh.Connection) error
+
+// State is threaded through every task: what the cluster is supposed to
+// look like (Cluster), what it was last found to actually look like
+// (LiveCluster), and the clients used to probe and change it.
+type State struct {
+	Context context.Context
+	Logger  logrus.FieldLogger
+
+	Cluster     *kubeoneapi.KubeOneCluster
+	LiveCluster *Cluster
+
+	Connector     ssh.Connector
+	DynamicClient dynclient.Client
+
+	// ReportSink receives the structured result of the probes/investigation
+	// tasks. When nil, callers fall back to the original free-form text
+	// output.
+	ReportSink report.Sink
+
+	// FlapSamples/FlapInterval configure the bounded flap sampler
+	// pkg/tasks.RunProbes runs against the kubelet and container runtime on
+	// every host. Zero means "use the package defaults".
+	FlapSamples  int
+	FlapInterval time.Duration
+}
+
+// Close releases nothing and always returns nil: the State keeps no SSH
+// connections open between tasks.
+func (s *State) Close() error {
+	return nil
+}
+
+// RunTaskOnControlPlane runs task against every control plane host.
+func (s *State) RunTaskOnControlPlane(task HostTask, mode RunMode) error {
+	return s.runTaskOnHosts(s.Cluster.ControlPlane.Hosts, task, mode)
+}
+
+// RunTaskOnAllNodes runs task against every control plane and static worker
+// host, so callers don't have to remember to fan out over both groups
+// separately.
+func (s *State) RunTaskOnAllNodes(task HostTask, mode RunMode) error {
+	hosts := make([]kubeoneapi.HostConfig, 0, len(s.Cluster.ControlPlane.Hosts)+len(s.Cluster.StaticWorkers.Hosts))
+	hosts = append(hosts, s.Cluster.ControlPlane.Hosts...)
+	hosts = append(hosts, s.Cluster.StaticWorkers.Hosts...)
+
+	return s.runTaskOnHosts(hosts, task, mode)
+}
+
+func (s *State) runTaskOnHosts(hosts []kubeoneapi.HostConfig, task HostTask, mode RunMode) error {
+	if mode == RunSequential {
+		for i := range hosts {
+			if err := s.runTaskOnHost(&hosts[i], task); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []string
+	)
+
+	for i := range hosts {
+		wg.Add(1)
+		go func(host *kubeoneapi.HostConfig) {
+			defer wg.Done()
+
+			if err := s.runTaskOnHost(host, task); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Sprintf("%s: %v", host.Hostname, err))
+				mu.Unlock()
+			}
+		}(&hosts[i])
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d host(s) failed: %s", len(errs), strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (s *State) runTaskOnHost(host *kubeoneapi.HostConfig, task HostTask) error {
+	conn, err := s.Connector.Connect(*host)
+	if err != nil {
+		return err
+	}
+
+	return task(s, host, conn)
+}
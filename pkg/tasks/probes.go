@@ -18,7 +18,10 @@ package tasks
 
 import (
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/pkg/errors"
@@ -27,19 +30,19 @@ import (
 	kubeoneapi "github.com/kubermatic/kubeone/pkg/apis/kubeone"
 	"github.com/kubermatic/kubeone/pkg/clusterstatus/apiserverstatus"
 	"github.com/kubermatic/kubeone/pkg/clusterstatus/etcdstatus"
-	"github.com/kubermatic/kubeone/pkg/clusterstatus/preflightstatus"
+	"github.com/kubermatic/kubeone/pkg/clusterstatus/report"
 	"github.com/kubermatic/kubeone/pkg/kubeconfig"
 	"github.com/kubermatic/kubeone/pkg/ssh"
 	"github.com/kubermatic/kubeone/pkg/state"
 
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/sets"
-	dynclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
-	systemdShowStatusCMD = `systemctl show %s -p LoadState,ActiveState,SubState`
+	systemdShowStatusCMD = `systemctl show %s -p LoadState,ActiveState,SubState,NRestarts`
+
+	journalctlSnippetCMD = `journalctl -u %s -n 50 --no-pager`
 
 	dockerVersionDPKG = `dpkg-query --show --showformat='${Version}' docker-ce | cut -d: -f2 | cut -d~ -f1`
 	dockerVersionRPM  = `rpm -qa --queryformat '%{RPMTAG_VERSION}' docker-ce`
@@ -49,8 +52,29 @@ const (
 	kubeletVersionCLI  = `kubelet --version | cut -d' ' -f2`
 
 	kubeletInitializedCMD = `test -f /etc/kubernetes/kubelet.conf`
+
+	// defaultFlapSamples/defaultFlapInterval bound how hard we look for a
+	// kubelet/container runtime stuck in a restart crashloop: re-sample
+	// systemd defaultFlapSamples times, defaultFlapInterval apart (so
+	// ~defaultFlapSamples*defaultFlapInterval in total) before trusting a
+	// single snapshot.
+	defaultFlapSamples  = 5
+	defaultFlapInterval = 2 * time.Second
 )
 
+// containerRuntimeUnits lists the systemd units probed, in priority order,
+// to determine which container runtime a host actually runs. The first unit
+// found loaded (and active, if more than one is loaded) wins.
+var containerRuntimeUnits = []string{"docker", "containerd", "crio"}
+
+// RunProbes investigates the live state of every control plane and static
+// worker host over SSH and reconciles it against the cluster configuration,
+// writing the result to s.ReportSink (or the original text output if none
+// is configured).
+func RunProbes(s *state.State) error {
+	return runProbes(s)
+}
+
 func runProbes(s *state.State) error {
 	expectedVersion, err := semver.NewVersion(s.Cluster.Versions.Kubernetes)
 	if err != nil {
@@ -67,7 +91,16 @@ func runProbes(s *state.State) error {
 		})
 	}
 
-	if err := s.RunTaskOnControlPlane(investigateHost, state.RunParallel); err != nil {
+	for i := range s.Cluster.StaticWorkers.Hosts {
+		s.LiveCluster.Workers = append(s.LiveCluster.Workers, state.Host{
+			Config: &s.Cluster.StaticWorkers.Hosts[i],
+		})
+	}
+
+	// RunTaskOnAllNodes runs investigateHost over both the control plane and
+	// the static workers, so worker drift is picked up the same way control
+	// plane drift always was.
+	if err := s.RunTaskOnAllNodes(investigateHost, state.RunParallel); err != nil {
 		return err
 	}
 
@@ -75,13 +108,14 @@ func runProbes(s *state.State) error {
 		return investigateCluster(s)
 	}
 
-	return nil
+	return reportSink(s).WriteClusterReport(report.ClusterReport{Hosts: hostReports(s)})
 }
 
 func investigateHost(s *state.State, node *kubeoneapi.HostConfig, conn ssh.Connection) error {
 	var (
-		idx int
-		h   *state.Host
+		idx     int
+		h       *state.Host
+		workers bool
 	)
 
 	s.LiveCluster.Lock.Lock()
@@ -93,13 +127,24 @@ func investigateHost(s *state.State, node *kubeoneapi.HostConfig, conn ssh.Conne
 			break
 		}
 	}
+	if h == nil {
+		for i := range s.LiveCluster.Workers {
+			host := s.LiveCluster.Workers[i]
+			if host.Config.Hostname == node.Hostname {
+				h = &host
+				idx = i
+				workers = true
+				break
+			}
+		}
+	}
 	s.LiveCluster.Lock.Unlock()
 
 	if h == nil {
 		return errors.New("didn't matched live cluster against provided")
 	}
 
-	if err := detectDockerStatusVersion(h, conn); err != nil {
+	if err := detectContainerRuntime(h, conn); err != nil {
 		return err
 	}
 
@@ -111,26 +156,17 @@ func investigateHost(s *state.State, node *kubeoneapi.HostConfig, conn ssh.Conne
 		return err
 	}
 
+	if err := detectFlapping(s, h, conn); err != nil {
+		return err
+	}
+
 	s.LiveCluster.Lock.Lock()
 
-	fmt.Println("---------------")
-	fmt.Printf("host: %q\n", h.Config.Hostname)
-	fmt.Printf("docker version: %q\n", h.ContainerRuntime.Version)
-	fmt.Printf("docker is installed?: %t\n", h.ContainerRuntime.Status&state.ComponentInstalled != 0)
-	fmt.Printf("docker is running?: %t\n", h.ContainerRuntime.Status&state.SystemDStatusRunning != 0)
-	fmt.Printf("docker is active?: %t\n", h.ContainerRuntime.Status&state.SystemDStatusActive != 0)
-	fmt.Printf("docker is restarting?: %t\n", h.ContainerRuntime.Status&state.SystemDStatusRestarting != 0)
-	fmt.Println()
-
-	fmt.Printf("kubelet version: %q\n", h.Kubelet.Version)
-	fmt.Printf("kubelet is installed?: %t\n", h.Kubelet.Status&state.ComponentInstalled != 0)
-	fmt.Printf("kubelet is running?: %t\n", h.Kubelet.Status&state.SystemDStatusRunning != 0)
-	fmt.Printf("kubelet is active?: %t\n", h.Kubelet.Status&state.SystemDStatusActive != 0)
-	fmt.Printf("kubelet is restarting?: %t\n", h.Kubelet.Status&state.SystemDStatusRestarting != 0)
-	fmt.Printf("kubelet is initialized?: %t\n", h.Kubelet.Status&state.KubeletInitialized != 0)
-	fmt.Println()
-
-	s.LiveCluster.ControlPlane[idx] = *h
+	if workers {
+		s.LiveCluster.Workers[idx] = *h
+	} else {
+		s.LiveCluster.ControlPlane[idx] = *h
+	}
 	s.LiveCluster.Lock.Unlock()
 	return nil
 }
@@ -186,12 +222,10 @@ func investigateCluster(s *state.State) error {
 		}
 	}
 
-	// Get the node list
+	// Get the node list. We list every node, not just the ones labeled as
+	// control plane, so that static workers are reconciled as well.
 	nodes := corev1.NodeList{}
-	nodeListOpts := dynclient.ListOptions{
-		LabelSelector: labels.SelectorFromSet(map[string]string{preflightstatus.LabelControlPlaneNode: ""}),
-	}
-	if err := s.DynamicClient.List(s.Context, &nodes, &nodeListOpts); err != nil {
+	if err := s.DynamicClient.List(s.Context, &nodes); err != nil {
 		return errors.Wrap(err, "unable to list nodes")
 	}
 
@@ -202,6 +236,9 @@ func investigateCluster(s *state.State) error {
 	for _, host := range s.LiveCluster.ControlPlane {
 		knownHostsIdentities.Insert(host.Config.Hostname)
 	}
+	for _, host := range s.LiveCluster.Workers {
+		knownHostsIdentities.Insert(host.Config.Hostname)
+	}
 
 	s.LiveCluster.Lock.Lock()
 	for _, node := range nodes.Items {
@@ -212,6 +249,11 @@ func investigateCluster(s *state.State) error {
 					s.LiveCluster.ControlPlane[i].IsInCluster = true
 				}
 			}
+			for i := range s.LiveCluster.Workers {
+				if node.Name == s.LiveCluster.Workers[i].Config.Hostname {
+					s.LiveCluster.Workers[i].IsInCluster = true
+				}
+			}
 		}
 	}
 	s.LiveCluster.Lock.Unlock()
@@ -222,33 +264,115 @@ func investigateCluster(s *state.State) error {
 	hostsToBeProvisioned := knownHostsIdentities.Difference(knownNodesIdentities)
 	nodesToBeRemoved := knownNodesIdentities.Difference(knownHostsIdentities)
 
-	fmt.Println()
-	fmt.Println("---------------")
-	fmt.Printf("Unprovisioned hosts: %q\n", hostsToBeProvisioned)
-	fmt.Printf("Nodes to be removed: %q\n", nodesToBeRemoved)
-	// fmt.Printf("Is cluster degraded: %t\n", s.LiveCluster.IsDegraded())
-	//fmt.Printf("Is cluster broken: %t\n", s.LiveCluster.IsBroken())
-	fmt.Println()
+	return reportSink(s).WriteClusterReport(report.ClusterReport{
+		Hosts:                hostReports(s),
+		QuorumHealthy:        leaderElected,
+		HostsToBeProvisioned: hostsToBeProvisioned.List(),
+		NodesToBeRemoved:     nodesToBeRemoved.List(),
+	})
+}
 
-	fmt.Println("---------------")
-	for _, host := range s.LiveCluster.ControlPlane {
-		fmt.Printf("API server running on %q: %t\n", host.Config.Hostname, host.APIServer.Status&state.PodRunning != 0)
-		fmt.Printf("Etcd running on %q: %t\n", host.Config.Hostname, host.Etcd.Status&state.PodRunning != 0)
+// reportSink returns the ReportSink configured on the state, falling back to
+// the original free-form text output when none was set.
+func reportSink(s *state.State) report.Sink {
+	if s.ReportSink != nil {
+		return s.ReportSink
 	}
-	fmt.Println()
 
-	return nil
+	return report.NewTextSink(os.Stdout)
 }
 
-func detectDockerStatusVersion(host *state.Host, conn ssh.Connection) error {
-	var err error
-	host.ContainerRuntime.Status, err = systemdStatus(conn, "docker")
-	if err != nil {
-		return err
+// hostReports snapshots the current LiveCluster into the report package's
+// machine-readable shape.
+func hostReports(s *state.State) []report.HostReport {
+	s.LiveCluster.Lock.Lock()
+	defer s.LiveCluster.Lock.Unlock()
+
+	hosts := make([]report.HostReport, 0, len(s.LiveCluster.ControlPlane)+len(s.LiveCluster.Workers))
+	for i := range s.LiveCluster.ControlPlane {
+		hosts = append(hosts, hostReport(&s.LiveCluster.ControlPlane[i], "control-plane"))
+	}
+	for i := range s.LiveCluster.Workers {
+		hosts = append(hosts, hostReport(&s.LiveCluster.Workers[i], "worker"))
+	}
+
+	return hosts
+}
+
+func hostReport(h *state.Host, role string) report.HostReport {
+	return report.HostReport{
+		Hostname:             h.Config.Hostname,
+		Role:                 role,
+		ContainerRuntimeName: h.ContainerRuntime.RuntimeName,
+		ContainerRuntime:     componentStatusReport(h.ContainerRuntime.Status, h.ContainerRuntime.Version, h.ContainerRuntime.RestartCount, h.ContainerRuntime.FlapDetected),
+		Kubelet:              componentStatusReport(h.Kubelet.Status, h.Kubelet.Version, h.Kubelet.RestartCount, h.Kubelet.FlapDetected),
+		KubeletInitialized:   h.Kubelet.Status&state.KubeletInitialized != 0,
+		APIServer:            report.ComponentStatus{Running: h.APIServer.Status&state.PodRunning != 0},
+		Etcd:                 report.ComponentStatus{Running: h.Etcd.Status&state.PodRunning != 0},
+		IsLeader:             h.Config.IsLeader,
+		IsInCluster:          h.IsInCluster,
+	}
+}
+
+func componentStatusReport(status uint64, version *semver.Version, restartCount int, flapDetected bool) report.ComponentStatus {
+	cs := report.ComponentStatus{
+		Installed:    status&state.ComponentInstalled != 0,
+		Running:      status&state.SystemDStatusRunning != 0,
+		Active:       status&state.SystemDStatusActive != 0,
+		Restarting:   status&state.SystemDStatusRestarting != 0,
+		RestartCount: restartCount,
+		FlapDetected: flapDetected,
+	}
+	if version != nil {
+		cs.Version = version.String()
 	}
 
-	if host.ContainerRuntime.Status&state.ComponentInstalled == 0 {
-		// docker is not installed
+	return cs
+}
+
+// detectContainerRuntime probes docker, containerd and cri-o systemd units
+// on the host and records whichever one is actually loaded as the host's
+// ContainerRuntime. When more than one is loaded (e.g. docker left behind
+// after a migration to containerd), the first one found active wins.
+func detectContainerRuntime(host *state.Host, conn ssh.Connection) error {
+	var (
+		chosen       string
+		chosenStatus uint64
+	)
+
+	for _, unit := range containerRuntimeUnits {
+		status, _, err := systemdStatus(conn, unit)
+		if err != nil {
+			return err
+		}
+
+		if status&state.ComponentInstalled == 0 {
+			// unit not loaded on this host
+			continue
+		}
+
+		if chosen == "" {
+			chosen, chosenStatus = unit, status
+			continue
+		}
+
+		// prefer whichever loaded unit is actually active
+		if chosenStatus&state.SystemDStatusActive == 0 && status&state.SystemDStatusActive != 0 {
+			chosen, chosenStatus = unit, status
+		}
+	}
+
+	if chosen == "" {
+		// nothing is installed, nothing left to detect
+		return nil
+	}
+
+	host.ContainerRuntime.RuntimeName = chosen
+	host.ContainerRuntime.Status = chosenStatus
+
+	if chosen != "docker" {
+		// we don't yet track package versions for containerd/cri-o,
+		// only which runtime the host is actually using
 		return nil
 	}
 
@@ -283,7 +407,7 @@ func detectDockerStatusVersion(host *state.Host, conn ssh.Connection) error {
 
 func detectKubeletStatusVersion(host *state.Host, conn ssh.Connection) error {
 	var err error
-	host.Kubelet.Status, err = systemdStatus(conn, "kubelet")
+	host.Kubelet.Status, _, err = systemdStatus(conn, "kubelet")
 	if err != nil {
 		return err
 	}
@@ -335,16 +459,68 @@ func detectKubeletInitialized(host *state.Host, conn ssh.Connection) error {
 	return nil
 }
 
-func systemdStatus(conn ssh.Connection, service string) (uint64, error) {
+// detectFlapping re-samples the kubelet and container runtime units to catch
+// a restart crashloop that a single systemctl snapshot would miss, and fails
+// fast with an actionable error rather than letting subsequent tasks apply
+// changes against a demonstrably broken node. The sample count/spacing
+// default to defaultFlapSamples/defaultFlapInterval but can be overridden via
+// s.FlapSamples/s.FlapInterval (wired to the --flap-samples/--flap-window
+// flags).
+func detectFlapping(s *state.State, host *state.Host, conn ssh.Connection) error {
+	samples := s.FlapSamples
+	if samples == 0 {
+		samples = defaultFlapSamples
+	}
+	interval := s.FlapInterval
+	if interval == 0 {
+		interval = defaultFlapInterval
+	}
+
+	restarts, flapped, err := sampleUnitHealth(conn, "kubelet", samples, interval)
+	if err != nil {
+		return err
+	}
+	host.Kubelet.RestartCount = restarts
+	host.Kubelet.FlapDetected = flapped
+	if flapped {
+		return errors.Errorf("kubelet on host %q is flapping (systemd NRestarts=%d), "+
+			"refusing to proceed; investigate with `journalctl -u kubelet`:\n%s",
+			host.Config.Hostname, restarts, journalSnippet(conn, "kubelet"))
+	}
+
+	if host.ContainerRuntime.RuntimeName == "" {
+		return nil
+	}
+
+	restarts, flapped, err = sampleUnitHealth(conn, host.ContainerRuntime.RuntimeName, samples, interval)
+	if err != nil {
+		return err
+	}
+	host.ContainerRuntime.RestartCount = restarts
+	host.ContainerRuntime.FlapDetected = flapped
+	if flapped {
+		return errors.Errorf("%s on host %q is flapping (systemd NRestarts=%d), "+
+			"refusing to proceed; investigate with `journalctl -u %s`:\n%s",
+			host.ContainerRuntime.RuntimeName, host.Config.Hostname, restarts, host.ContainerRuntime.RuntimeName,
+			journalSnippet(conn, host.ContainerRuntime.RuntimeName))
+	}
+
+	return nil
+}
+
+// systemdStatus returns the decoded component status flags together with
+// the unit's NRestarts counter, which the flap sampler uses to tell a
+// one-off restart from a crashloop.
+func systemdStatus(conn ssh.Connection, service string) (uint64, int, error) {
 	out, _, _, err := conn.Exec(fmt.Sprintf(systemdShowStatusCMD, service))
 	if err != nil {
-		return 0, err
+		return 0, 0, err
 	}
 
 	out = strings.ReplaceAll(out, "=", ": ")
 	m := map[string]string{}
 	if err = yaml.Unmarshal([]byte(out), &m); err != nil {
-		return 0, err
+		return 0, 0, err
 	}
 
 	var status uint64
@@ -369,5 +545,74 @@ func systemdStatus(conn ssh.Connection, service string) (uint64, error) {
 		status |= state.SystemDStatusUnknown
 	}
 
-	return status, nil
+	nrestarts, err := strconv.Atoi(m["NRestarts"])
+	if err != nil {
+		// Older systemd releases don't expose NRestarts; treat as unknown
+		// rather than failing the whole probe over it.
+		nrestarts = 0
+	}
+
+	return status, nrestarts, nil
+}
+
+// sampleUnitHealth re-issues `systemctl show` samples times, interval apart,
+// to tell a kubelet/container runtime that is genuinely stuck in a restart
+// crashloop from one that merely restarted once. It watches the NRestarts
+// counter, transitions in and out of the "auto-restart" substate, and a unit
+// that never leaves "auto-restart" for the entire sampling window — a
+// backoff-stuck crashloop that neither an NRestarts bump nor a substate
+// transition would otherwise catch.
+func sampleUnitHealth(conn ssh.Connection, unit string, samples int, interval time.Duration) (restartCount int, flapped bool, err error) {
+	if samples < 1 {
+		samples = 1
+	}
+
+	var (
+		firstRestarts    = -1
+		lastRestarts     int
+		prevRestarting   bool
+		transitions      int
+		alwaysRestarting = true
+	)
+
+	for i := 0; i < samples; i++ {
+		status, nrestarts, serr := systemdStatus(conn, unit)
+		if serr != nil {
+			return 0, false, serr
+		}
+
+		if firstRestarts < 0 {
+			firstRestarts = nrestarts
+		}
+		lastRestarts = nrestarts
+
+		restarting := status&state.SystemDStatusRestarting != 0
+		if !restarting {
+			alwaysRestarting = false
+		}
+		if i > 0 && restarting != prevRestarting {
+			transitions++
+		}
+		prevRestarting = restarting
+
+		if i < samples-1 {
+			time.Sleep(interval)
+		}
+	}
+
+	restartCount = lastRestarts
+	flapped = lastRestarts-firstRestarts >= 2 || transitions >= 2 || (samples > 1 && alwaysRestarting)
+
+	return restartCount, flapped, nil
+}
+
+// journalSnippet fetches the last few lines of a unit's journal over SSH so
+// a flap-detected error can point straight at the cause.
+func journalSnippet(conn ssh.Connection, unit string) string {
+	out, _, _, err := conn.Exec(fmt.Sprintf(journalctlSnippetCMD, unit))
+	if err != nil {
+		return fmt.Sprintf("(unable to fetch journal for %s: %v)", unit, err)
+	}
+
+	return out
 }
@@ -0,0 +1,97 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+
+	"github.com/kubermatic/kubeone/pkg/kubeadm/apiversion"
+	"github.com/kubermatic/kubeone/pkg/state"
+	"github.com/kubermatic/kubeone/pkg/upgrade/plan"
+)
+
+// ApplyUpgradePlan walks p's steps in the order p prescribes (leader control
+// plane, then the rest of the control plane, then static workers), resolving
+// each host and selecting its kubeadm config API version. It does not run a
+// kubeadm upgrade: the first step returns an error naming the host, the
+// target version and the selected API version.
+func ApplyUpgradePlan(s *state.State, p *plan.Plan) error {
+	for _, step := range p.Steps {
+		host, err := s.LiveCluster.FindHost(step.Host)
+		if err != nil {
+			return errors.Wrapf(err, "upgrade step for %q", step.Host)
+		}
+
+		switch step.Action {
+		case plan.ActionUpgradeLeader, plan.ActionUpgradeControlPlane:
+			if err := upgradeControlPlaneHost(s, host, step); err != nil {
+				return err
+			}
+		case plan.ActionUpgradeWorker:
+			if err := upgradeWorkerHost(s, host, step); err != nil {
+				return err
+			}
+		default:
+			return errors.Errorf("unknown upgrade action %q", step.Action)
+		}
+	}
+
+	return nil
+}
+
+// ApplyAddonsUpgrade does not roll out addons; it always returns an error
+// naming the target addons version.
+func ApplyAddonsUpgrade(s *state.State, targetVersion string) error {
+	return errors.Errorf("addon upgrade to %q not implemented yet", targetVersion)
+}
+
+// ApplyOSImageUpgrade does not change any host's OS image; it always returns
+// an error naming the target image.
+func ApplyOSImageUpgrade(s *state.State, targetImage string) error {
+	return errors.Errorf("OS image upgrade to %q not implemented yet", targetImage)
+}
+
+func upgradeControlPlaneHost(s *state.State, host *state.Host, step plan.Step) error {
+	target, err := semver.NewVersion(step.ToVersion)
+	if err != nil {
+		return errors.Wrapf(err, "upgrade step for %q", step.Host)
+	}
+
+	// Each control plane host renders the kubeadm config its own,
+	// not-yet-upgraded kubeadm binary understands.
+	apiVersion := apiversion.Select(host.Kubelet.Version, target)
+
+	// The actual kubeadm upgrade apply/node task isn't wired in yet, so fail
+	// loudly instead of reporting a silent no-op success.
+	return errors.Errorf("upgrade of control plane host %q to %q (kubeadm config %s) not implemented yet",
+		step.Host, step.ToVersion, apiVersion)
+}
+
+func upgradeWorkerHost(s *state.State, host *state.Host, step plan.Step) error {
+	target, err := semver.NewVersion(step.ToVersion)
+	if err != nil {
+		return errors.Wrapf(err, "upgrade step for %q", step.Host)
+	}
+
+	apiVersion := apiversion.Select(host.Kubelet.Version, target)
+
+	// The actual kubeadm upgrade node task isn't wired in yet, so fail loudly
+	// instead of reporting a silent no-op success.
+	return errors.Errorf("upgrade of worker host %q to %q (kubeadm config %s) not implemented yet",
+		step.Host, step.ToVersion, apiVersion)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+
+	"github.com/kubermatic/kubeone/pkg/state"
+)
+
+// Targets is the set of independently steerable upgrade targets a single
+// `kubeone upgrade --plan` invocation can be asked to compute: the
+// Kubernetes version itself, the cluster addons, and (for providers that
+// manage it) the host OS image.
+type Targets struct {
+	Kubernetes string
+	Addons     string
+	OSImage    string
+}
+
+// AddonsStep/OSImageStep are cluster-wide, not per-host: addons and OS
+// images are rolled out independently of the kubeadm/kubelet skew rules
+// that govern the Kubernetes sub-plan.
+type AddonsStep struct {
+	FromVersion string
+	ToVersion   string
+}
+
+type OSImageStep struct {
+	FromImage string
+	ToImage   string
+}
+
+// CoordinatedPlan bundles the three sub-plans produced for one upgrade run.
+// They're validated together so that an addons bump whose version encodes a
+// Kubernetes minor requirement (kubeone addons are versioned vMAJOR.MINOR.x
+// against the Kubernetes minor they target) can't be scheduled ahead of the
+// Kubernetes sub-plan that would provide it.
+type CoordinatedPlan struct {
+	Kubernetes *Plan
+	Addons     *AddonsStep
+	OSImage    *OSImageStep
+}
+
+// ComputeCoordinated computes the Kubernetes sub-plan via Compute, plus the
+// addons and OS image sub-plans, and cross-validates the addons target
+// against whichever Kubernetes minor the coordinated plan actually reaches
+// (the Kubernetes sub-plan's target if one was requested, otherwise the
+// minor the cluster is already running).
+func ComputeCoordinated(live *state.Cluster, targets Targets) (*CoordinatedPlan, error) {
+	var cp CoordinatedPlan
+
+	reachedKubernetes := liveExpectedVersion(live)
+
+	if targets.Kubernetes != "" {
+		targetVersion, err := semver.NewVersion(targets.Kubernetes)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid --target-kubernetes")
+		}
+
+		kubePlan, err := Compute(live, targetVersion)
+		if err != nil {
+			return nil, err
+		}
+		cp.Kubernetes = kubePlan
+		reachedKubernetes = targetVersion
+	}
+
+	if targets.Addons != "" {
+		if err := checkAddonsKubernetesCompatibility(targets.Addons, reachedKubernetes); err != nil {
+			return nil, err
+		}
+
+		cp.Addons = &AddonsStep{
+			FromVersion: live.AddonsVersion,
+			ToVersion:   targets.Addons,
+		}
+	}
+
+	if targets.OSImage != "" {
+		cp.OSImage = &OSImageStep{
+			FromImage: live.OSImage,
+			ToImage:   targets.OSImage,
+		}
+	}
+
+	if cp.Kubernetes == nil && cp.Addons == nil && cp.OSImage == nil {
+		return nil, errors.New("at least one of --target-kubernetes, --target-addons, --target-os-image must be set")
+	}
+
+	return &cp, nil
+}
+
+// liveExpectedVersion reports the Kubernetes version the cluster is
+// currently expected to be running, used as the baseline a non-bumped
+// addons target is checked against.
+func liveExpectedVersion(live *state.Cluster) *semver.Version {
+	if live == nil {
+		return nil
+	}
+
+	return live.ExpectedVersion
+}
+
+// checkAddonsKubernetesCompatibility rejects an addons target that requires
+// a newer Kubernetes minor than reachedKubernetes. Not every addons version
+// follows kubeone's vMAJOR.MINOR.x scheme; one that doesn't parse as a
+// semver is let through unchecked since there's nothing to compare it
+// against.
+func checkAddonsKubernetesCompatibility(addonsVersion string, reachedKubernetes *semver.Version) error {
+	required, err := semver.NewVersion(addonsVersion)
+	if err != nil {
+		return nil
+	}
+
+	if reachedKubernetes == nil {
+		return errors.Errorf("--target-addons %s requires Kubernetes 1.%d, but the Kubernetes version this upgrade reaches is unknown", addonsVersion, required.Minor())
+	}
+
+	if required.Minor() > reachedKubernetes.Minor() {
+		return errors.Errorf("--target-addons %s requires Kubernetes 1.%d, but this upgrade only reaches 1.%d; bump --target-kubernetes first",
+			addonsVersion, required.Minor(), reachedKubernetes.Minor())
+	}
+
+	return nil
+}
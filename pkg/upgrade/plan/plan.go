@@ -0,0 +1,232 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package plan computes an ordered, skew-aware Kubernetes upgrade plan from
+// the probed live state of a cluster to a target version.
+package plan
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+
+	"github.com/kubermatic/kubeone/pkg/state"
+)
+
+// Action identifies what a Step does to a single host.
+type Action string
+
+const (
+	ActionUpgradeLeader       Action = "upgrade-leader-control-plane"
+	ActionUpgradeControlPlane Action = "upgrade-control-plane"
+	ActionUpgradeWorker       Action = "upgrade-worker"
+)
+
+// Step is one host's move from its current Kubernetes version to the next.
+type Step struct {
+	Host        string
+	Action      Action
+	FromVersion string
+	ToVersion   string
+}
+
+// Plan is the ordered list of Steps needed to bring every host in the
+// cluster up to the target Kubernetes version: leader control plane first,
+// then the remaining control plane, then static workers.
+type Plan struct {
+	Steps []Step
+}
+
+// dockershimRemovedMinor is the first Kubernetes minor that no longer ships
+// the in-tree dockershim, so kubeadm can no longer drive docker directly as
+// a container runtime from this minor onward.
+const dockershimRemovedMinor = 24
+
+// runtimeMinVersions gives, per recognized container runtime, the minimum
+// runtime version Compute will plan an upgrade onto. Hosts running an older
+// runtime, an unrecognized one, or (from dockershimRemovedMinor onward)
+// docker at all, cause Compute to refuse the plan rather than produce a step
+// that would fail partway through the upgrade.
+var runtimeMinVersions = map[string]*semver.Version{}
+
+func init() {
+	minimums := map[string]string{
+		"docker":     "18.9.0",
+		"containerd": "1.3.0",
+		"crio":       "1.18.0",
+	}
+
+	for name, v := range minimums {
+		parsed, err := semver.NewVersion(v)
+		if err != nil {
+			panic(err)
+		}
+		runtimeMinVersions[name] = parsed
+	}
+}
+
+// Compute builds the upgrade Plan for moving every host in live from its
+// currently probed version to target.
+//
+// It enforces kubeadm/kubelet skew policy:
+//   - the control plane is upgraded one minor at a time (no minor jump > 1)
+//   - no kubelet may end up newer than the target API server version
+//   - every host's container runtime must appear in the compatibility
+//     matrix for the target minor
+func Compute(live *state.Cluster, target *semver.Version) (*Plan, error) {
+	if live == nil || !live.IsProvisioned() {
+		return nil, errors.New("cannot plan an upgrade for a non-provisioned cluster")
+	}
+
+	var p Plan
+
+	leaderIdx := -1
+	for i := range live.ControlPlane {
+		if live.ControlPlane[i].Config.IsLeader {
+			leaderIdx = i
+			break
+		}
+	}
+	if leaderIdx < 0 {
+		return nil, errors.New("cannot plan an upgrade without an elected control plane leader")
+	}
+
+	order := append([]int{leaderIdx}, otherIndexes(len(live.ControlPlane), leaderIdx)...)
+	for _, i := range order {
+		host := live.ControlPlane[i]
+		action := ActionUpgradeControlPlane
+		if i == leaderIdx {
+			action = ActionUpgradeLeader
+		}
+
+		step, err := controlPlaneStep(host, target, action)
+		if err != nil {
+			return nil, err
+		}
+		p.Steps = append(p.Steps, step)
+	}
+
+	for i := range live.Workers {
+		step, err := workerStep(live.Workers[i], target)
+		if err != nil {
+			return nil, err
+		}
+		p.Steps = append(p.Steps, step)
+	}
+
+	return &p, nil
+}
+
+func controlPlaneStep(host state.Host, target *semver.Version, action Action) (Step, error) {
+	if err := checkSkew(host, target); err != nil {
+		return Step{}, err
+	}
+
+	return Step{
+		Host:        host.Config.Hostname,
+		Action:      action,
+		FromVersion: host.Kubelet.Version.String(),
+		ToVersion:   target.String(),
+	}, nil
+}
+
+func workerStep(host state.Host, target *semver.Version) (Step, error) {
+	if err := checkSkew(host, target); err != nil {
+		return Step{}, err
+	}
+
+	return Step{
+		Host:        host.Config.Hostname,
+		Action:      ActionUpgradeWorker,
+		FromVersion: host.Kubelet.Version.String(),
+		ToVersion:   target.String(),
+	}, nil
+}
+
+func checkSkew(host state.Host, target *semver.Version) error {
+	current := host.Kubelet.Version
+	if current == nil {
+		return errors.Errorf("host %q has no probed kubelet version, run probes before planning an upgrade", host.Config.Hostname)
+	}
+
+	if current.Minor() > target.Minor() || (current.Minor() == target.Minor() && current.Patch() > target.Patch()) {
+		return errors.Errorf("host %q runs kubelet %s which is newer than target %s", host.Config.Hostname, current, target)
+	}
+
+	if target.Minor()-current.Minor() > 1 {
+		return errors.Errorf("host %q would jump more than one minor version (%s -> %s), upgrade it incrementally instead", host.Config.Hostname, current, target)
+	}
+
+	return checkRuntimeCompatibility(host, target)
+}
+
+// checkRuntimeCompatibility rejects a host whose container runtime (or
+// runtime version) kubeadm can't drive on the target Kubernetes minor:
+// docker once dockershim is gone, or any runtime older than the minimum
+// version known to work with kubeadm.
+func checkRuntimeCompatibility(host state.Host, target *semver.Version) error {
+	name := host.ContainerRuntime.RuntimeName
+	if name == "" {
+		return errors.Errorf("host %q has no probed container runtime, run probes before planning an upgrade", host.Config.Hostname)
+	}
+
+	minVersion, known := runtimeMinVersions[name]
+	if !known {
+		return errors.Errorf("host %q runs unrecognized container runtime %q", host.Config.Hostname, name)
+	}
+
+	if name == "docker" && target.Minor() >= dockershimRemovedMinor {
+		return errors.Errorf("host %q runs docker, which kubeadm can no longer use as a container runtime on Kubernetes 1.%d or newer (dockershim was removed in 1.%d); migrate to containerd or cri-o first",
+			host.Config.Hostname, target.Minor(), dockershimRemovedMinor)
+	}
+
+	version := host.ContainerRuntime.Version
+	if version == nil {
+		// containerd/cri-o aren't version-probed today; nothing further to
+		// compare.
+		return nil
+	}
+
+	if version.LessThan(minVersion) {
+		return errors.Errorf("host %q runs %s %s, which is older than the minimum %s required to upgrade to Kubernetes 1.%d",
+			host.Config.Hostname, name, version, minVersion, target.Minor())
+	}
+
+	return nil
+}
+
+func otherIndexes(n, exclude int) []int {
+	out := make([]int, 0, n-1)
+	for i := 0; i < n; i++ {
+		if i != exclude {
+			out = append(out, i)
+		}
+	}
+
+	return out
+}
+
+// String renders the Plan as the ordered, human-readable action list printed
+// by `kubeone upgrade --plan`.
+func (p *Plan) String() string {
+	var out string
+	for i, step := range p.Steps {
+		out += fmt.Sprintf("%d. %s %s: %s -> %s\n", i+1, step.Action, step.Host, step.FromVersion, step.ToVersion)
+	}
+
+	return out
+}
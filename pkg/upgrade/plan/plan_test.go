@@ -0,0 +1,185 @@
+/*
+Copyright 2019 The KubeOne Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver"
+
+	kubeoneapi "github.com/kubermatic/kubeone/pkg/apis/kubeone"
+	"github.com/kubermatic/kubeone/pkg/state"
+)
+
+func mustVersion(t *testing.T, v string) *semver.Version {
+	t.Helper()
+
+	parsed, err := semver.NewVersion(v)
+	if err != nil {
+		t.Fatalf("failed to parse version %q: %v", v, err)
+	}
+
+	return parsed
+}
+
+func leaderHost(t *testing.T, name, kubeletVersion, runtime, runtimeVersion string) state.Host {
+	h := state.Host{
+		Config: &kubeoneapi.HostConfig{Hostname: name, IsLeader: true},
+		Kubelet: state.ComponentState{
+			Status:  state.KubeletInitialized,
+			Version: mustVersion(t, kubeletVersion),
+		},
+		ContainerRuntime: state.ContainerRuntimeState{
+			RuntimeName: runtime,
+		},
+	}
+	if runtimeVersion != "" {
+		h.ContainerRuntime.Version = mustVersion(t, runtimeVersion)
+	}
+
+	return h
+}
+
+func TestComputeValidPlan(t *testing.T) {
+	live := &state.Cluster{
+		ControlPlane: []state.Host{leaderHost(t, "cp-1", "1.22.3", "containerd", "1.5.0")},
+	}
+
+	p, err := Compute(live, mustVersion(t, "1.23.0"))
+	if err != nil {
+		t.Fatalf("Compute() returned unexpected error: %v", err)
+	}
+	if len(p.Steps) != 1 {
+		t.Fatalf("expected 1 step, got %d", len(p.Steps))
+	}
+	if p.Steps[0].Action != ActionUpgradeLeader {
+		t.Errorf("expected leader step, got %q", p.Steps[0].Action)
+	}
+}
+
+func TestComputeRejectsNonProvisionedCluster(t *testing.T) {
+	live := &state.Cluster{
+		ControlPlane: []state.Host{{Config: &kubeoneapi.HostConfig{Hostname: "cp-1"}}},
+	}
+
+	if _, err := Compute(live, mustVersion(t, "1.23.0")); err == nil {
+		t.Fatal("expected an error for a non-provisioned cluster, got nil")
+	}
+}
+
+func TestCheckSkewRejectsDowngrade(t *testing.T) {
+	host := leaderHost(t, "cp-1", "1.24.0", "containerd", "1.5.0")
+
+	err := checkSkew(host, mustVersion(t, "1.23.0"))
+	if err == nil || !strings.Contains(err.Error(), "newer than target") {
+		t.Fatalf("expected a newer-than-target error, got %v", err)
+	}
+}
+
+func TestCheckSkewRejectsMultiMinorJump(t *testing.T) {
+	host := leaderHost(t, "cp-1", "1.21.0", "containerd", "1.5.0")
+
+	err := checkSkew(host, mustVersion(t, "1.23.0"))
+	if err == nil || !strings.Contains(err.Error(), "more than one minor") {
+		t.Fatalf("expected a minor-jump error, got %v", err)
+	}
+}
+
+func TestCheckSkewRejectsDockerAfterDockershimRemoval(t *testing.T) {
+	host := leaderHost(t, "cp-1", "1.23.0", "docker", "20.10.0")
+
+	err := checkSkew(host, mustVersion(t, "1.24.0"))
+	if err == nil || !strings.Contains(err.Error(), "dockershim") {
+		t.Fatalf("expected a dockershim-removed error, got %v", err)
+	}
+}
+
+func TestCheckSkewAllowsDockerBeforeDockershimRemoval(t *testing.T) {
+	host := leaderHost(t, "cp-1", "1.22.0", "docker", "20.10.0")
+
+	if err := checkSkew(host, mustVersion(t, "1.23.0")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCheckSkewRejectsOldRuntimeVersion(t *testing.T) {
+	host := leaderHost(t, "cp-1", "1.22.0", "crio", "1.10.0")
+
+	err := checkSkew(host, mustVersion(t, "1.23.0"))
+	if err == nil || !strings.Contains(err.Error(), "older than the minimum") {
+		t.Fatalf("expected a too-old-runtime error, got %v", err)
+	}
+}
+
+func TestCheckSkewRejectsUnrecognizedRuntime(t *testing.T) {
+	host := leaderHost(t, "cp-1", "1.22.0", "rkt", "")
+
+	err := checkSkew(host, mustVersion(t, "1.23.0"))
+	if err == nil || !strings.Contains(err.Error(), "unrecognized") {
+		t.Fatalf("expected an unrecognized-runtime error, got %v", err)
+	}
+}
+
+func TestComputeCoordinatedRejectsAddonsAheadOfKubernetes(t *testing.T) {
+	live := &state.Cluster{
+		ExpectedVersion: mustVersion(t, "1.22.3"),
+		ControlPlane:    []state.Host{leaderHost(t, "cp-1", "1.22.3", "containerd", "1.5.0")},
+	}
+
+	_, err := ComputeCoordinated(live, Targets{Addons: "1.23.0"})
+	if err == nil || !strings.Contains(err.Error(), "requires Kubernetes 1.23") {
+		t.Fatalf("expected an addons-ahead-of-kubernetes error, got %v", err)
+	}
+}
+
+func TestComputeCoordinatedAllowsAddonsCoveredByKubernetesBump(t *testing.T) {
+	live := &state.Cluster{
+		ExpectedVersion: mustVersion(t, "1.22.3"),
+		ControlPlane:    []state.Host{leaderHost(t, "cp-1", "1.22.3", "containerd", "1.5.0")},
+	}
+
+	cp, err := ComputeCoordinated(live, Targets{Kubernetes: "1.23.0", Addons: "1.23.0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cp.Kubernetes == nil || cp.Addons == nil {
+		t.Fatal("expected both a Kubernetes and an Addons sub-plan")
+	}
+}
+
+func TestComputeCoordinatedAllowsAddonsAlreadyCoveredByLiveVersion(t *testing.T) {
+	live := &state.Cluster{
+		ExpectedVersion: mustVersion(t, "1.23.3"),
+		ControlPlane:    []state.Host{leaderHost(t, "cp-1", "1.23.3", "containerd", "1.5.0")},
+	}
+
+	if _, err := ComputeCoordinated(live, Targets{Addons: "1.23.0"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestComputeCoordinatedAllowsNonSemverAddonsVersion(t *testing.T) {
+	live := &state.Cluster{
+		ExpectedVersion: mustVersion(t, "1.22.3"),
+		ControlPlane:    []state.Host{leaderHost(t, "cp-1", "1.22.3", "containerd", "1.5.0")},
+	}
+
+	if _, err := ComputeCoordinated(live, Targets{Addons: "edge"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}